@@ -0,0 +1,489 @@
+package stdlib
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Array returns an interface suitable for use as a driver.Valuer and sql.Scanner for the given slice, mirroring the
+// API of lib/pq's Array. It is intended for use with database/sql, where pgx has no opportunity to see that a
+// []string, []int64, []bool, [][]byte or []float64 argument should be encoded as a Postgres array rather than
+// passed straight through to the driver.
+//
+//	db.QueryRow("select * from widgets where id = any($1)", stdlib.Array([]int64{1, 2, 3}))
+//	var names stdlib.StringArray
+//	err := db.QueryRow("select names from widgets where id = $1", id).Scan(&names)
+//
+// For scanning, prefer the concrete BoolArray, ByteaArray, Float64Array, Int64Array or StringArray types directly;
+// Array falls back to a reflection-based GenericArray for any other slice type.
+func Array(a interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+} {
+	switch a := a.(type) {
+	case []bool:
+		return (*BoolArray)(&a)
+	case [][]byte:
+		return (*ByteaArray)(&a)
+	case []float64:
+		return (*Float64Array)(&a)
+	case []int64:
+		return (*Int64Array)(&a)
+	case []string:
+		return (*StringArray)(&a)
+
+	case *[]bool:
+		return (*BoolArray)(a)
+	case *[][]byte:
+		return (*ByteaArray)(a)
+	case *[]float64:
+		return (*Float64Array)(a)
+	case *[]int64:
+		return (*Int64Array)(a)
+	case *[]string:
+		return (*StringArray)(a)
+	}
+
+	return GenericArray{A: a}
+}
+
+// BoolArray is a []bool that implements driver.Valuer and sql.Scanner, encoding to and decoding from the Postgres
+// array text format (e.g. "{t,f}"). Scan returns an error for a NULL element.
+type BoolArray []bool
+
+func (a BoolArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(a))
+	for i, v := range a {
+		if v {
+			elems[i] = "true"
+		} else {
+			elems[i] = "false"
+		}
+	}
+
+	return formatArray(elems), nil
+}
+
+func (a *BoolArray) Scan(src interface{}) error {
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	out := make(BoolArray, len(elems))
+	for i, e := range elems {
+		if !e.Valid {
+			return fmt.Errorf("stdlib: parsing array element %d: NULL is not allowed in BoolArray", i)
+		}
+		b, err := strconv.ParseBool(e.String)
+		if err != nil {
+			return fmt.Errorf("stdlib: parsing array element %d: %v", i, err)
+		}
+		out[i] = b
+	}
+
+	*a = out
+	return nil
+}
+
+// ByteaArray is a [][]byte that implements driver.Valuer and sql.Scanner, encoding each element using the Postgres
+// bytea hex format (e.g. `{"\\x00010203"}`).
+type ByteaArray [][]byte
+
+func (a ByteaArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(a))
+	for i, v := range a {
+		elems[i] = `\x` + hex.EncodeToString(v)
+	}
+
+	return formatArray(elems), nil
+}
+
+func (a *ByteaArray) Scan(src interface{}) error {
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	out := make(ByteaArray, len(elems))
+	for i, e := range elems {
+		if !e.Valid {
+			out[i] = nil
+			continue
+		}
+		if !strings.HasPrefix(e.String, `\x`) {
+			return fmt.Errorf("stdlib: parsing array element %d: invalid bytea %q, expected \\x prefix", i, e.String)
+		}
+		b, err := hex.DecodeString(e.String[2:])
+		if err != nil {
+			return fmt.Errorf("stdlib: parsing array element %d: %v", i, err)
+		}
+		out[i] = b
+	}
+
+	*a = out
+	return nil
+}
+
+// Float64Array is a []float64 that implements driver.Valuer and sql.Scanner, encoding to and decoding from the
+// Postgres array text format.
+type Float64Array []float64
+
+func (a Float64Array) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(a))
+	for i, v := range a {
+		elems[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return formatArray(elems), nil
+}
+
+func (a *Float64Array) Scan(src interface{}) error {
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	out := make(Float64Array, len(elems))
+	for i, e := range elems {
+		if !e.Valid {
+			return fmt.Errorf("stdlib: parsing array element %d: NULL is not allowed in Float64Array", i)
+		}
+		f, err := strconv.ParseFloat(e.String, 64)
+		if err != nil {
+			return fmt.Errorf("stdlib: parsing array element %d: %v", i, err)
+		}
+		out[i] = f
+	}
+
+	*a = out
+	return nil
+}
+
+// Int64Array is a []int64 that implements driver.Valuer and sql.Scanner, encoding to and decoding from the Postgres
+// array text format.
+type Int64Array []int64
+
+func (a Int64Array) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(a))
+	for i, v := range a {
+		elems[i] = strconv.FormatInt(v, 10)
+	}
+
+	return formatArray(elems), nil
+}
+
+func (a *Int64Array) Scan(src interface{}) error {
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	out := make(Int64Array, len(elems))
+	for i, e := range elems {
+		if !e.Valid {
+			return fmt.Errorf("stdlib: parsing array element %d: NULL is not allowed in Int64Array", i)
+		}
+		n, err := strconv.ParseInt(e.String, 10, 64)
+		if err != nil {
+			return fmt.Errorf("stdlib: parsing array element %d: %v", i, err)
+		}
+		out[i] = n
+	}
+
+	*a = out
+	return nil
+}
+
+// StringArray is a []string that implements driver.Valuer and sql.Scanner, encoding to and decoding from the
+// Postgres array text format.
+type StringArray []string
+
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(a))
+	copy(elems, a)
+
+	return formatArray(elems), nil
+}
+
+func (a *StringArray) Scan(src interface{}) error {
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		*a = nil
+		return nil
+	}
+
+	out := make(StringArray, len(elems))
+	for i, e := range elems {
+		if !e.Valid {
+			return fmt.Errorf("stdlib: parsing array element %d: NULL is not allowed in StringArray", i)
+		}
+		out[i] = e.String
+	}
+
+	*a = out
+	return nil
+}
+
+// GenericArray implements driver.Valuer and sql.Scanner for a slice of any element type, using reflection. A is
+// the slice (for Value) or a pointer to a slice (for Scan). Prefer the concrete *Array types above for bool,
+// []byte, float64, int64 and string elements; they avoid this reflection overhead and are used automatically by
+// Array for those element types.
+//
+// On encoding, []byte elements (including those returned by a driver.Valuer element) are bytea-hex-encoded like
+// ByteaArray; every other element type is rendered with fmt.Sprintf("%v", ...), which only yields a valid
+// Postgres literal for string, integer, float and bool kinds.
+type GenericArray struct {
+	A interface{}
+}
+
+func (a GenericArray) Value() (driver.Value, error) {
+	if a.A == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(a.A)
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+	case reflect.Array:
+	default:
+		return nil, fmt.Errorf("stdlib: Array: expected slice or array, got %T", a.A)
+	}
+
+	n := v.Len()
+	elems := make([]string, n)
+	for i := 0; i < n; i++ {
+		elem := v.Index(i).Interface()
+		if valuer, ok := elem.(driver.Valuer); ok {
+			val, err := valuer.Value()
+			if err != nil {
+				return nil, fmt.Errorf("stdlib: Array: encoding element %d: %w", i, err)
+			}
+			elems[i] = formatGenericElem(val)
+		} else {
+			elems[i] = formatGenericElem(elem)
+		}
+	}
+
+	return formatArray(elems), nil
+}
+
+// formatGenericElem renders a single GenericArray element (or the driver.Value produced by a driver.Valuer
+// element) as Postgres array element text. []byte is encoded using the same bytea hex format as ByteaArray, since
+// fmt.Sprintf would otherwise render it as Go's "[104 105]" rather than a valid bytea literal. Every other type
+// falls back to fmt.Sprintf("%v", ...), which only produces a valid Postgres literal for string, integer, float
+// and bool kinds; other element kinds are not supported by this reflection-based path.
+func formatGenericElem(val interface{}) string {
+	if b, ok := val.([]byte); ok {
+		return `\x` + hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+func (a GenericArray) Scan(src interface{}) error {
+	dpv := reflect.ValueOf(a.A)
+	if dpv.Kind() != reflect.Ptr {
+		return fmt.Errorf("stdlib: Array: Scan destination is not a pointer, got %T", a.A)
+	}
+
+	dv := reflect.Indirect(dpv)
+	if dv.Kind() != reflect.Slice {
+		return fmt.Errorf("stdlib: Array: Scan destination is not a pointer to a slice, got %T", a.A)
+	}
+
+	elems, err := scanArray(src)
+	if err != nil {
+		return err
+	}
+	if elems == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+
+	elemType := dv.Type().Elem()
+	out := reflect.MakeSlice(dv.Type(), len(elems), len(elems))
+	for i, e := range elems {
+		elemPtr := reflect.New(elemType)
+		if scanner, ok := elemPtr.Interface().(sql.Scanner); ok {
+			var scanSrc interface{}
+			if e.Valid {
+				scanSrc = e.String
+			}
+			if err := scanner.Scan(scanSrc); err != nil {
+				return fmt.Errorf("stdlib: Array: scanning element %d: %v", i, err)
+			}
+			out.Index(i).Set(elemPtr.Elem())
+			continue
+		}
+
+		if !e.Valid {
+			return fmt.Errorf("stdlib: Array: parsing element %d: NULL is not allowed for %v", i, elemType)
+		}
+
+		switch elemType.Kind() {
+		case reflect.String:
+			out.Index(i).SetString(e.String)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(e.String, 10, elemType.Bits())
+			if err != nil {
+				return fmt.Errorf("stdlib: Array: parsing element %d: %v", i, err)
+			}
+			out.Index(i).SetInt(n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(e.String, elemType.Bits())
+			if err != nil {
+				return fmt.Errorf("stdlib: Array: parsing element %d: %v", i, err)
+			}
+			out.Index(i).SetFloat(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(e.String)
+			if err != nil {
+				return fmt.Errorf("stdlib: Array: parsing element %d: %v", i, err)
+			}
+			out.Index(i).SetBool(b)
+		default:
+			return fmt.Errorf("stdlib: Array: unsupported element type %v", elemType)
+		}
+	}
+
+	dv.Set(out)
+	return nil
+}
+
+// formatArray renders elems (already individually formatted) as a quoted Postgres array literal.
+func formatArray(elems []string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range elems {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('"')
+		for _, r := range e {
+			if r == '"' || r == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(r)
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// scanArray converts src (expected to be a Postgres array in text format, as a string or []byte) into its
+// unquoted elements. It returns a nil slice if src is nil (SQL NULL).
+func scanArray(src interface{}) ([]sql.NullString, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	var s string
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return nil, fmt.Errorf("stdlib: cannot scan %T into an array", src)
+	}
+
+	return parseArray(s)
+}
+
+// parseArray parses a 1-dimensional Postgres array in text format (e.g. `{a,"b c",NULL}`), returning one
+// sql.NullString per element with quoting and backslash escaping removed. An unquoted "NULL" (case-insensitive)
+// denotes a SQL NULL element.
+func parseArray(s string) ([]sql.NullString, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("stdlib: unable to parse array %q: expected '{' at start and '}' at end", s)
+	}
+	s = s[1 : len(s)-1]
+
+	if len(s) == 0 {
+		return []sql.NullString{}, nil
+	}
+
+	var elems []sql.NullString
+	var buf bytes.Buffer
+	var inQuotes, sawQuotes, escaped bool
+
+	flush := func() {
+		if !sawQuotes && strings.EqualFold(buf.String(), "NULL") {
+			elems = append(elems, sql.NullString{})
+		} else {
+			elems = append(elems, sql.NullString{String: buf.String(), Valid: true})
+		}
+		buf.Reset()
+		sawQuotes = false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			buf.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			inQuotes = !inQuotes
+			sawQuotes = true
+		case c == ',' && !inQuotes:
+			flush()
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	flush()
+
+	return elems, nil
+}
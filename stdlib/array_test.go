@@ -0,0 +1,118 @@
+package stdlib
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestFormatArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		elems []string
+		want  string
+	}{
+		{"empty", []string{}, "{}"},
+		{"simple", []string{"a", "b", "c"}, `{"a","b","c"}`},
+		{"quote and backslash escaped", []string{`a"b`, `a\b`}, `{"a\"b","a\\b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatArray(tt.elems); got != tt.want {
+				t.Errorf("formatArray(%#v) = %q, want %q", tt.elems, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []sql.NullString
+		wantErr bool
+	}{
+		{
+			name: "empty array",
+			s:    "{}",
+			want: []sql.NullString{},
+		},
+		{
+			name: "unquoted elements",
+			s:    "{a,b,c}",
+			want: []sql.NullString{{String: "a", Valid: true}, {String: "b", Valid: true}, {String: "c", Valid: true}},
+		},
+		{
+			name: "quoted element with comma and space",
+			s:    `{a,"b, c",d}`,
+			want: []sql.NullString{
+				{String: "a", Valid: true},
+				{String: "b, c", Valid: true},
+				{String: "d", Valid: true},
+			},
+		},
+		{
+			name: "backslash-escaped quote",
+			s:    `{"a\"b"}`,
+			want: []sql.NullString{{String: `a"b`, Valid: true}},
+		},
+		{
+			name: "unquoted NULL is SQL NULL",
+			s:    "{a,NULL,null}",
+			want: []sql.NullString{
+				{String: "a", Valid: true},
+				{},
+				{},
+			},
+		},
+		{
+			name: "quoted NULL is the literal string",
+			s:    `{"NULL"}`,
+			want: []sql.NullString{{String: "NULL", Valid: true}},
+		},
+		{
+			name:    "missing braces",
+			s:       "a,b,c",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArray(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseArray(%q): expected error, got nil", tt.s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArray(%q) returned error: %v", tt.s, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseArray(%q) = %#v, want %#v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatGenericElem(t *testing.T) {
+	tests := []struct {
+		name string
+		val  interface{}
+		want string
+	}{
+		{"bytes are bytea-hex-encoded", []byte{0x68, 0x69}, `\x6869`},
+		{"string falls back to Sprintf", "hi", "hi"},
+		{"int falls back to Sprintf", 42, "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGenericElem(tt.val); got != tt.want {
+				t.Errorf("formatGenericElem(%#v) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	errors "golang.org/x/xerrors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// ReconnectNotification is sent on a Listener's notification channel immediately after a reconnect and re-LISTEN,
+// before any notification actually received from Postgres, so that subscribers know they may have missed
+// notifications while the connection was down. It carries no payload and is only ever compared by identity.
+var ReconnectNotification = &pgconn.Notification{}
+
+var errListenerClosed = errors.New("stdlib: listener closed")
+
+// errNeedResubscribe is returned internally by serve to report that it returned because Listen or Unlisten asked
+// for a channel subscription change or PingInterval expired, not because the connection failed. It never escapes
+// run.
+var errNeedResubscribe = errors.New("stdlib: listener resubscribing")
+
+// ListenerConfig configures a Listener created by NewListener.
+type ListenerConfig struct {
+	// MinReconnect is the delay after the first failed reconnect attempt; the first attempt after a connection is
+	// lost is made at once. Defaults to 1 second.
+	MinReconnect time.Duration
+
+	// MaxReconnect is the maximum delay between reconnect attempts; each failed attempt doubles the delay up to
+	// this bound. Defaults to 1 minute.
+	MaxReconnect time.Duration
+
+	// PingInterval bounds how long a single WaitForNotification call on the listening connection is allowed to
+	// block. It is the only way to notice that connection's socket has gone half-open while it is sitting idle
+	// with nothing to deliver: Postgres never sends anything on its own to reveal that, so without a deadline a
+	// dead listening connection can go undetected for as long as the OS keepalive timeout (often hours). Expiry
+	// forces a reconnect-and-resubscribe (see serve and waitContext), so this trades idle-connection churn for
+	// bounded detection latency. Defaults to 30 seconds.
+	PingInterval time.Duration
+}
+
+func (c *ListenerConfig) setDefaults() {
+	if c.MinReconnect <= 0 {
+		c.MinReconnect = time.Second
+	}
+	if c.MaxReconnect <= 0 {
+		c.MaxReconnect = time.Minute
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = 30 * time.Second
+	}
+}
+
+// Listener provides a long-lived LISTEN/NOTIFY subscription on top of a *sql.DB opened with the pgx driver, without
+// requiring the application to manage a hijacked *pgx.Conn (see AcquireConn/ReleaseConn) itself. It holds a single
+// dedicated connection for the lifetime of the Listener, transparently reconnecting and re-issuing LISTEN for every
+// registered channel if that connection is lost.
+//
+// The listening connection blocks on WaitForNotification with a context bounded by PingInterval rather than polling
+// it with a short timeout: pgconn v4 has no way to resume a read after its context is canceled mid-receive, only to
+// abandon the connection, so canceling the wait for any reason forces a reconnect. Listen, Unlisten and Close
+// interrupt the wait deliberately; PingInterval's deadline interrupts it incidentally, as the only way to bound how
+// long a half-open socket on the listening connection itself can go unnoticed. Either way the cost is the same
+// reconnect-and-resubscribe cycle; see serve and waitContext.
+type Listener struct {
+	db     *sql.DB
+	config ListenerConfig
+
+	notifications chan *pgconn.Notification
+
+	mu          sync.Mutex
+	channels    map[string]struct{}
+	pendingCmds []chan error
+
+	wake      chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewListener creates a Listener and starts its background connection goroutine. db must be a *sql.DB opened with
+// the pgx driver (e.g. via OpenDB or sql.Open("pgx", ...)).
+func NewListener(db *sql.DB, config ListenerConfig) (*Listener, error) {
+	config.setDefaults()
+
+	l := &Listener{
+		db:            db,
+		config:        config,
+		notifications: make(chan *pgconn.Notification),
+		channels:      make(map[string]struct{}),
+		wake:          make(chan struct{}, 1),
+		closed:        make(chan struct{}),
+	}
+
+	l.wg.Add(1)
+	go l.run()
+
+	return l, nil
+}
+
+// Notifications returns the channel on which notifications for every channel passed to Listen are delivered. It is
+// closed after Close. A delivered value equal to ReconnectNotification (by identity) indicates the Listener
+// reconnected and notifications may have been missed in the meantime.
+func (l *Listener) Notifications() <-chan *pgconn.Notification {
+	return l.notifications
+}
+
+// Listen subscribes to channel. It blocks until LISTEN has actually been issued on the listening connection, which
+// may require interrupting an in-progress WaitForNotification and reconnecting (see the Listener doc comment), or
+// until ctx is done.
+func (l *Listener) Listen(ctx context.Context, channel string) error {
+	return l.sendCommand(ctx, func() { l.channels[channel] = struct{}{} })
+}
+
+// Unlisten unsubscribes from channel.
+func (l *Listener) Unlisten(ctx context.Context, channel string) error {
+	return l.sendCommand(ctx, func() { delete(l.channels, channel) })
+}
+
+// sendCommand applies updateChannels to l.channels, wakes run so it notices the new channel set (interrupting its
+// blocking wait on the listening connection if one is in progress), and waits for the LISTEN/UNLISTEN this produces
+// on every currently registered channel to complete.
+func (l *Listener) sendCommand(ctx context.Context, updateChannels func()) error {
+	errCh := make(chan error, 1)
+
+	l.mu.Lock()
+	updateChannels()
+	l.pendingCmds = append(l.pendingCmds, errCh)
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+		// A wake is already pending and run hasn't consumed it yet; it will see this command too once it does.
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-l.closed:
+		return errListenerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notifyPending reports err to every command queued since the last notifyPending call.
+func (l *Listener) notifyPending(err error) {
+	l.mu.Lock()
+	pending := l.pendingCmds
+	l.pendingCmds = nil
+	l.mu.Unlock()
+
+	for _, errCh := range pending {
+		errCh <- err
+	}
+}
+
+// Close stops the Listener and releases its connection. It is safe to call more than once.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	l.wg.Wait()
+	return nil
+}
+
+func (l *Listener) run() {
+	defer l.wg.Done()
+	defer close(l.notifications)
+
+	backoff := l.config.MinReconnect
+	announceReconnect := false
+
+	for {
+		select {
+		case <-l.closed:
+			return
+		default:
+		}
+
+		conn, err := AcquireConn(l.db)
+		if err != nil {
+			select {
+			case <-l.closed:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = nextBackoff(backoff, l.config.MaxReconnect)
+			continue
+		}
+		backoff = l.config.MinReconnect
+
+		err = l.serve(conn, announceReconnect)
+		ReleaseConn(l.db, conn)
+
+		switch err {
+		case errListenerClosed:
+			return
+		case errNeedResubscribe:
+			// Listen/Unlisten or PingInterval expiry is why the wait was interrupted, not a failure; the next serve
+			// call resubscribes from the now-current l.channels, so subscribers don't need a ReconnectNotification
+			// about it.
+			announceReconnect = false
+		default:
+			announceReconnect = true
+		}
+	}
+}
+
+// serve issues LISTEN for every registered channel on conn, then forwards notifications until conn fails, the
+// Listener is closed, or Listen/Unlisten asks for a subscription change or PingInterval expires
+// (errNeedResubscribe).
+func (l *Listener) serve(conn *pgx.Conn, isReconnect bool) error {
+	err := l.listenAll(conn)
+	l.notifyPending(err)
+	if err != nil {
+		return err
+	}
+
+	if isReconnect {
+		select {
+		case l.notifications <- ReconnectNotification:
+		case <-l.closed:
+			return errListenerClosed
+		}
+	}
+
+	for {
+		select {
+		case <-l.closed:
+			return errListenerClosed
+		case <-l.wake:
+			return errNeedResubscribe
+		default:
+		}
+
+		ctx, cancel := l.waitContext()
+		n, err := conn.WaitForNotification(ctx)
+		cancel()
+		if err != nil {
+			select {
+			case <-l.closed:
+				return errListenerClosed
+			case <-l.wake:
+				return errNeedResubscribe
+			default:
+				if errors.Is(err, context.DeadlineExceeded) {
+					// PingInterval expired, not a failure: resubscribing on a fresh connection is exactly how we
+					// find out whether the old one was actually still alive.
+					return errNeedResubscribe
+				}
+				return err
+			}
+		}
+
+		select {
+		case l.notifications <- n:
+		case <-l.closed:
+			return errListenerClosed
+		}
+	}
+}
+
+// waitContext returns a context for a single WaitForNotification call: one that is canceled if the Listener is
+// closed, woken by Listen/Unlisten, or PingInterval elapses, whichever comes first. The PingInterval bound is what
+// lets serve actually detect a half-open socket on the listening connection itself, rather than merely assuming it
+// is alive because nothing reported otherwise; see the Listener and ListenerConfig.PingInterval doc comments. The
+// caller must invoke the returned cancel func once WaitForNotification returns (whether or not this context caused
+// it) to stop the watcher goroutine below from leaking.
+func (l *Listener) waitContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.PingInterval)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-l.closed:
+			cancel()
+		case <-l.wake:
+			// Put the wake back so the select at the top of serve's loop also observes it and returns
+			// errNeedResubscribe, instead of looping into another WaitForNotification on a connection we're about
+			// to abandon.
+			select {
+			case l.wake <- struct{}{}:
+			default:
+			}
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		cancel()
+	}
+}
+
+func (l *Listener) listenAll(conn *pgx.Conn) error {
+	l.mu.Lock()
+	channels := make([]string, 0, len(l.channels))
+	for ch := range l.channels {
+		channels = append(channels, ch)
+	}
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// conn may be a pooled connection that still listens on channels removed by Unlisten since.
+	if _, err := conn.Exec(ctx, "unlisten *"); err != nil {
+		return err
+	}
+
+	for _, ch := range channels {
+		if _, err := conn.Exec(ctx, "listen "+quoteIdentifier(ch)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+// nextBackoff doubles cur and adds up to 20% jitter so that multiple Listeners reconnecting at once do not all
+// retry in lockstep, then caps the result at max. Once the cap is reached every call returns exactly max.
+func nextBackoff(cur, max time.Duration) time.Duration {
+	next := cur * 2
+	if next > max || next <= 0 {
+		next = max
+	}
+	jitter := time.Duration(rand.Int63n(int64(next)/5 + 1))
+	next += jitter
+	if next > max {
+		next = max
+	}
+	return next
+}
@@ -0,0 +1,42 @@
+package stdlib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		cur, max time.Duration
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{"doubles within bound", time.Second, time.Minute, 2 * time.Second, 2*time.Second + 2*time.Second/5 + 1},
+		{"caps at max", 40 * time.Second, time.Minute, time.Minute, time.Minute + time.Minute/5 + 1},
+		{"zero max falls back to max", time.Second, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := nextBackoff(tt.cur, tt.max)
+				if got < tt.wantMin || got > tt.wantMax {
+					t.Fatalf("nextBackoff(%v, %v) = %v, want in [%v, %v]", tt.cur, tt.max, got, tt.wantMin, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestNextBackoffNeverDecreasesBelowCur(t *testing.T) {
+	cur := time.Second
+	max := time.Minute
+	for i := 0; i < 100; i++ {
+		next := nextBackoff(cur, max)
+		if next < cur {
+			t.Fatalf("nextBackoff(%v, %v) = %v, want >= %v", cur, max, next, cur)
+		}
+		cur = next
+	}
+}
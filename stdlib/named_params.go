@@ -0,0 +1,209 @@
+package stdlib
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	errors "golang.org/x/xerrors"
+)
+
+// NamedParamStyle selects the sigil used to recognize named parameters when OptionNamedParameters is in effect.
+type NamedParamStyle int
+
+const (
+	// NamedParamStyleNone disables named-parameter rewriting; this is the default, and matches pgx's normal
+	// positional-only $N parameters.
+	NamedParamStyleNone NamedParamStyle = iota
+
+	// NamedParamColon recognizes parameters written as :name.
+	NamedParamColon
+
+	// NamedParamAt recognizes parameters written as @name.
+	NamedParamAt
+)
+
+// rewriteNamedQuery rewrites every occurrence of a named parameter in query (as recognized by style) to the
+// positional $N pgx expects, skipping over string literals, dollar-quoted strings, quoted identifiers and
+// comments so that sigil characters occurring inside them are left untouched. names is returned in first-use
+// order, with $1 corresponding to names[0], and so on; it is nil if style is NamedParamStyleNone or query does not
+// reference any named parameter, in which case query is returned unmodified.
+//
+// Under NamedParamColon, a ':' immediately preceded by a digit, ']' or identifier character is assumed to be a
+// Postgres array subscript or slice bound (e.g. tags[1:n], tags[i:n]) rather than a named parameter, and is left
+// untouched; see isArraySliceColon.
+func rewriteNamedQuery(style NamedParamStyle, query string) (string, []string, error) {
+	var sigil byte
+	switch style {
+	case NamedParamStyleNone:
+		return query, nil, nil
+	case NamedParamColon:
+		sigil = ':'
+	case NamedParamAt:
+		sigil = '@'
+	default:
+		return "", nil, fmt.Errorf("stdlib: unknown NamedParamStyle %d", style)
+	}
+
+	var out strings.Builder
+	var names []string
+	index := make(map[string]int)
+
+	n := len(query)
+	for i := 0; i < n; {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			j := skipQuoted(query, i, c)
+			out.WriteString(query[i:j])
+			i = j
+		case c == '-' && i+1 < n && query[i+1] == '-':
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				out.WriteString(query[i:])
+				i = n
+			} else {
+				out.WriteString(query[i : i+j+1])
+				i += j + 1
+			}
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			end := indexBlockCommentEnd(query, i)
+			out.WriteString(query[i:end])
+			i = end
+		case c == '$' && i+1 < n && isDollarQuoteStart(query, i):
+			end := dollarQuoteEnd(query, i)
+			out.WriteString(query[i:end])
+			i = end
+		// A doubled ':' is PostgreSQL's type-cast operator (e.g. id::int, $1::text[]), not a named parameter;
+		// pass it through untouched so ::casts survive NamedParamColon rewriting.
+		case c == ':' && i+1 < n && query[i+1] == ':':
+			out.WriteString("::")
+			i += 2
+		case c == sigil && i+1 < n && isNameStart(query[i+1]) && !isArraySliceColon(sigil, query, i):
+			j := i + 1
+			for j < n && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			idx, ok := index[name]
+			if !ok {
+				idx = len(names)
+				index[name] = idx
+				names = append(names, name)
+			}
+			fmt.Fprintf(&out, "$%d", idx+1)
+			i = j
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	if len(names) == 0 {
+		return query, nil, nil
+	}
+
+	return out.String(), names, nil
+}
+
+// reorderNamedArgs translates argsV, as populated by database/sql from sql.Named arguments, into positional order
+// matching names (the result of rewriteNamedQuery): names[i] becomes ordinal i+1.
+func reorderNamedArgs(names []string, argsV []driver.NamedValue) ([]driver.NamedValue, error) {
+	byName := make(map[string]driver.NamedValue, len(argsV))
+	for _, v := range argsV {
+		if v.Name == "" {
+			return nil, errors.New("stdlib: query uses named parameters; arguments must be passed via sql.Named")
+		}
+		byName[v.Name] = v
+	}
+
+	out := make([]driver.NamedValue, len(names))
+	for i, name := range names {
+		v, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("stdlib: query references named parameter %q with no matching sql.Named argument", name)
+		}
+		delete(byName, name)
+		out[i] = driver.NamedValue{Ordinal: i + 1, Value: v.Value}
+	}
+
+	if len(byName) > 0 {
+		extra := make([]string, 0, len(byName))
+		for name := range byName {
+			extra = append(extra, name)
+		}
+		return nil, fmt.Errorf("stdlib: sql.Named argument(s) not referenced in query: %s", strings.Join(extra, ", "))
+	}
+
+	return out, nil
+}
+
+// isArraySliceColon reports whether the ':' at s[i] is a Postgres array subscript/slice bound (e.g. tags[1:n],
+// tags[i:n]) rather than the start of a named parameter: in that position it is immediately preceded by a digit,
+// ']' or identifier character, none of which can precede a genuine named parameter (that always follows an
+// operator, punctuation or whitespace). Only applies to the ':' sigil; Postgres array slicing has no '@' form.
+func isArraySliceColon(sigil byte, s string, i int) bool {
+	if sigil != ':' || i == 0 {
+		return false
+	}
+	prev := s[i-1]
+	return prev == ']' || isNameChar(prev)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// skipQuoted returns the index just past the closing quote of a quoted literal starting at s[i] (quote is a single
+// or double quote character), treating two consecutive quote characters as an escaped quote rather than the end of
+// the literal.
+func skipQuoted(s string, i int, quote byte) int {
+	j := i + 1
+	for j < len(s) {
+		if s[j] == quote {
+			if j+1 < len(s) && s[j+1] == quote {
+				j += 2
+				continue
+			}
+			return j + 1
+		}
+		j++
+	}
+	return len(s)
+}
+
+func indexBlockCommentEnd(s string, i int) int {
+	j := strings.Index(s[i+2:], "*/")
+	if j < 0 {
+		return len(s)
+	}
+	return i + 2 + j + 2
+}
+
+// isDollarQuoteStart reports whether s[i] begins a dollar-quoted string tag, i.e. $tag$ where tag is a possibly
+// empty identifier.
+func isDollarQuoteStart(s string, i int) bool {
+	j := i + 1
+	for j < len(s) && isNameChar(s[j]) {
+		j++
+	}
+	return j < len(s) && s[j] == '$'
+}
+
+// dollarQuoteEnd returns the index just past the closing $tag$ of the dollar-quoted string starting at s[i].
+func dollarQuoteEnd(s string, i int) int {
+	j := i + 1
+	for j < len(s) && isNameChar(s[j]) {
+		j++
+	}
+	tag := s[i : j+1] // includes both '$' delimiters
+	end := strings.Index(s[j+1:], tag)
+	if end < 0 {
+		return len(s)
+	}
+	return j + 1 + end + len(tag)
+}
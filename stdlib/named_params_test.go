@@ -0,0 +1,208 @@
+package stdlib
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteNamedQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		style     NamedParamStyle
+		query     string
+		wantQuery string
+		wantNames []string
+	}{
+		{
+			name:      "disabled",
+			style:     NamedParamStyleNone,
+			query:     "select * from widgets where id = :id",
+			wantQuery: "select * from widgets where id = :id",
+			wantNames: nil,
+		},
+		{
+			name:      "no named parameters",
+			style:     NamedParamColon,
+			query:     "select * from widgets where id = $1",
+			wantQuery: "select * from widgets where id = $1",
+			wantNames: nil,
+		},
+		{
+			name:      "colon style, repeated name reuses ordinal",
+			style:     NamedParamColon,
+			query:     "select * from widgets where id = :id or parent_id = :id",
+			wantQuery: "select * from widgets where id = $1 or parent_id = $1",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "at style",
+			style:     NamedParamAt,
+			query:     "update widgets set name = @name where id = @id",
+			wantQuery: "update widgets set name = $1 where id = $2",
+			wantNames: []string{"name", "id"},
+		},
+		{
+			name:      "skips single-quoted strings",
+			style:     NamedParamColon,
+			query:     "select ':notaparam' , :id",
+			wantQuery: "select ':notaparam' , $1",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "skips doubled single quote inside string",
+			style:     NamedParamColon,
+			query:     "select 'it''s :notaparam', :id",
+			wantQuery: "select 'it''s :notaparam', $1",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "skips double-quoted identifiers",
+			style:     NamedParamColon,
+			query:     `select ":notaparam" from widgets where id = :id`,
+			wantQuery: `select ":notaparam" from widgets where id = $1`,
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "skips line comments",
+			style:     NamedParamColon,
+			query:     "select :id -- :notaparam\nfrom widgets",
+			wantQuery: "select $1 -- :notaparam\nfrom widgets",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "skips block comments",
+			style:     NamedParamColon,
+			query:     "select :id /* :notaparam */ from widgets",
+			wantQuery: "select $1 /* :notaparam */ from widgets",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "skips dollar-quoted strings",
+			style:     NamedParamColon,
+			query:     "select $tag$ :notaparam $tag$, :id",
+			wantQuery: "select $tag$ :notaparam $tag$, $1",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "double colon cast is left untouched",
+			style:     NamedParamColon,
+			query:     "select :id::int, :name::text[]",
+			wantQuery: "select $1::int, $2::text[]",
+			wantNames: []string{"id", "name"},
+		},
+		{
+			name:      "double colon cast directly on a positional parameter",
+			style:     NamedParamColon,
+			query:     "select $1::int where x = :id",
+			wantQuery: "select $1::int where x = $1",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "array slice with digit lower bound is left untouched",
+			style:     NamedParamColon,
+			query:     "select tags[1:n] from t",
+			wantQuery: "select tags[1:n] from t",
+			wantNames: nil,
+		},
+		{
+			name:      "array slice with identifier lower bound is left untouched",
+			style:     NamedParamColon,
+			query:     "select tags[i:n] from t",
+			wantQuery: "select tags[i:n] from t",
+			wantNames: nil,
+		},
+		{
+			name:      "array slice alongside a real named parameter",
+			style:     NamedParamColon,
+			query:     "select tags[1:n], :id from t",
+			wantQuery: "select tags[1:n], $1 from t",
+			wantNames: []string{"id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotQuery, gotNames, err := rewriteNamedQuery(tt.style, tt.query)
+			if err != nil {
+				t.Fatalf("rewriteNamedQuery returned error: %v", err)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(gotNames, tt.wantNames) {
+				t.Errorf("names = %#v, want %#v", gotNames, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestReorderNamedArgs(t *testing.T) {
+	names := []string{"id", "name"}
+	argsV := []driver.NamedValue{
+		{Name: "name", Value: "foo"},
+		{Name: "id", Value: int64(1)},
+	}
+
+	got, err := reorderNamedArgs(names, argsV)
+	if err != nil {
+		t.Fatalf("reorderNamedArgs returned error: %v", err)
+	}
+
+	want := []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "foo"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestReorderNamedArgsMissingValue(t *testing.T) {
+	_, err := reorderNamedArgs([]string{"id"}, nil)
+	if err == nil {
+		t.Fatal("expected error for missing named argument, got nil")
+	}
+}
+
+func TestReorderNamedArgsExtraValue(t *testing.T) {
+	_, err := reorderNamedArgs([]string{"id"}, []driver.NamedValue{
+		{Name: "id", Value: 1},
+		{Name: "extra", Value: 2},
+	})
+	if err == nil {
+		t.Fatal("expected error for unreferenced named argument, got nil")
+	}
+}
+
+func TestReorderNamedArgsRequiresNames(t *testing.T) {
+	_, err := reorderNamedArgs([]string{"id"}, []driver.NamedValue{{Ordinal: 1, Value: 1}})
+	if err == nil {
+		t.Fatal("expected error when arguments are not passed via sql.Named, got nil")
+	}
+}
+
+func TestIsArraySliceColon(t *testing.T) {
+	tests := []struct {
+		name  string
+		sigil byte
+		s     string
+		i     int
+		want  bool
+	}{
+		{"digit before colon", ':', "tags[1:n]", 6, true},
+		{"identifier before colon", ':', "tags[i:n]", 6, true},
+		{"close bracket before colon", ':', "a]:b", 2, true},
+		{"colon at start of string", ':', ":id", 0, false},
+		{"space before colon is a real parameter", ':', "x = :id", 4, false},
+		{"at-style sigil is never a slice colon", '@', "tags[1:n]", 6, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isArraySliceColon(tt.sigil, tt.s, tt.i); got != tt.want {
+				t.Errorf("isArraySliceColon(%q, %q, %d) = %v, want %v", tt.sigil, tt.s, tt.i, got, tt.want)
+			}
+		})
+	}
+}
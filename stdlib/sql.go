@@ -24,10 +24,16 @@
 //	db, _ := sql.Open("pgx", connStr)
 //
 // pgx uses standard PostgreSQL positional parameters in queries. e.g. $1, $2.
-// It does not support named parameters.
+// It does not support named parameters by default.
 //
 //	db.QueryRow("select * from users where id=$1", userID)
 //
+// Named parameters (:name or @name) can be enabled with OptionNamedParameters. Queries are rewritten to
+// positional parameters before being sent to Postgres, so arguments must be passed via sql.Named.
+//
+//	db := stdlib.OpenDB(*connConfig, stdlib.OptionNamedParameters(stdlib.NamedParamColon))
+//	db.QueryRow("select * from users where id=:id", sql.Named("id", userID))
+//
 // AcquireConn and ReleaseConn acquire and release a *pgx.Conn from the standard
 // database/sql.DB connection pool. This allows operations that must be
 // performed on a single connection without running in a transaction, and it
@@ -83,6 +89,10 @@ type ctxKey int
 
 var ctxKeyFakeTx ctxKey = 0
 
+// ctxKeyRetryConfig is used by retryConfigFor (tx.go) to read a Conn's configured *RetryConfig through a BeginTx
+// call, the same way ctxKeyFakeTx is used by AcquireConn to get at the underlying *pgx.Conn.
+var ctxKeyRetryConfig ctxKey = 1
+
 var ErrNotPgx = errors.New("not pgx *sql.DB")
 
 func init() {
@@ -124,6 +134,18 @@ func OptionAfterConnect(ac func(context.Context, *pgx.Conn) error) OptionOpenDB
 	}
 }
 
+// OptionNamedParameters enables an opt-in named-parameter mode in which queries may reference parameters by name
+// (using the given style) instead of the positional $N parameters pgx normally requires. Referenced names are
+// rewritten to $N in first-use order before being sent to Postgres, so arguments must be passed via sql.Named.
+//
+//	db := stdlib.OpenDB(*connConfig, stdlib.OptionNamedParameters(stdlib.NamedParamColon))
+//	db.Exec("update widgets set name = :name where id = :id", sql.Named("name", "foo"), sql.Named("id", 1))
+func OptionNamedParameters(style NamedParamStyle) OptionOpenDB {
+	return func(dc *connector) {
+		dc.NamedParamStyle = style
+	}
+}
+
 func OpenDB(config pgx.ConnConfig, opts ...OptionOpenDB) *sql.DB {
 	c := connector{
 		ConnConfig:   config,
@@ -140,8 +162,11 @@ func OpenDB(config pgx.ConnConfig, opts ...OptionOpenDB) *sql.DB {
 
 type connector struct {
 	pgx.ConnConfig
-	AfterConnect func(context.Context, *pgx.Conn) error // function to call on every new connection
-	driver       *Driver
+	AfterConnect    func(context.Context, *pgx.Conn) error // function to call on every new connection
+	NamedParamStyle NamedParamStyle                        // set via OptionNamedParameters
+	Tracer          QueryTracer                            // set via OptionQueryTracer
+	RetryConfig     *RetryConfig                           // set via OptionRetryOnSerializationFailure
+	driver          *Driver
 }
 
 // Connect implement driver.Connector interface
@@ -151,6 +176,14 @@ func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
 		conn *pgx.Conn
 	)
 
+	if c.Tracer != nil {
+		start := time.Now()
+		ctx = c.Tracer.TraceConnectStart(ctx, TraceConnectStartData{ConnConfig: &c.ConnConfig})
+		defer func() {
+			c.Tracer.TraceConnectEnd(ctx, TraceConnectEndData{Conn: conn, Err: err, Duration: time.Since(start)})
+		}()
+	}
+
 	if conn, err = pgx.ConnectConfig(ctx, &c.ConnConfig); err != nil {
 		return nil, err
 	}
@@ -159,7 +192,10 @@ func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
 		return nil, err
 	}
 
-	return &Conn{conn: conn, driver: c.driver, connConfig: c.ConnConfig}, nil
+	return &Conn{
+		conn: conn, driver: c.driver, connConfig: c.ConnConfig,
+		namedParamStyle: c.NamedParamStyle, tracer: c.Tracer, retryConfig: c.RetryConfig,
+	}, nil
 }
 
 // Driver implement driver.Connector interface
@@ -251,10 +287,13 @@ func UnregisterConnConfig(connStr string) {
 }
 
 type Conn struct {
-	conn       *pgx.Conn
-	psCount    int64 // Counter used for creating unique prepared statement names
-	driver     *Driver
-	connConfig pgx.ConnConfig
+	conn            *pgx.Conn
+	psCount         int64 // Counter used for creating unique prepared statement names
+	driver          *Driver
+	connConfig      pgx.ConnConfig
+	namedParamStyle NamedParamStyle
+	tracer          QueryTracer
+	retryConfig     *RetryConfig // set via OptionRetryOnSerializationFailure; read by retryConfigFor (tx.go)
 }
 
 func (c *Conn) Prepare(query string) (driver.Stmt, error) {
@@ -266,6 +305,19 @@ func (c *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, e
 		return nil, driver.ErrBadConn
 	}
 
+	query, names, err := rewriteNamedQuery(c.namedParamStyle, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.tracer != nil {
+		start := time.Now()
+		ctx = c.tracer.TraceQueryStart(ctx, c.conn, TraceQueryStartData{SQL: query})
+		defer func() {
+			c.tracer.TraceQueryEnd(ctx, c.conn, TraceQueryEndData{Err: err, Duration: time.Since(start)})
+		}()
+	}
+
 	name := fmt.Sprintf("pgx_%d", c.psCount)
 	c.psCount++
 
@@ -274,13 +326,17 @@ func (c *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, e
 		return nil, err
 	}
 
-	return &Stmt{sd: sd, conn: c}, nil
+	return &Stmt{sd: sd, conn: c, names: names}, nil
 }
 
 func (c *Conn) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	return c.conn.Close(ctx)
+	err := c.conn.Close(ctx)
+	if c.tracer != nil {
+		c.tracer.TraceClose(ctx, c.conn, TraceCloseData{Err: err})
+	}
+	return err
 }
 
 func (c *Conn) Begin() (driver.Tx, error) {
@@ -297,6 +353,11 @@ func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 		return fakeTx{}, nil
 	}
 
+	if prc, ok := ctx.Value(ctxKeyRetryConfig).(**RetryConfig); ok {
+		*prc = c.retryConfig
+		return fakeTx{}, nil
+	}
+
 	var pgxOpts pgx.TxOptions
 	switch sql.IsolationLevel(opts.Isolation) {
 	case sql.LevelDefault:
@@ -316,12 +377,16 @@ func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 		pgxOpts.AccessMode = pgx.ReadOnly
 	}
 
+	if c.tracer != nil {
+		ctx = c.tracer.TraceTxStart(ctx, c.conn, TraceTxStartData{Isolation: pgxOpts.IsoLevel, ReadOnly: opts.ReadOnly})
+	}
+
 	tx, err := c.conn.BeginTx(ctx, pgxOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	return wrapTx{ctx: ctx, tx: tx}, nil
+	return wrapTx{ctx: ctx, tx: tx, conn: c.conn, tracer: c.tracer}, nil
 }
 
 func (c *Conn) ExecContext(ctx context.Context, query string, argsV []driver.NamedValue) (driver.Result, error) {
@@ -329,9 +394,29 @@ func (c *Conn) ExecContext(ctx context.Context, query string, argsV []driver.Nam
 		return nil, driver.ErrBadConn
 	}
 
+	query, names, err := rewriteNamedQuery(c.namedParamStyle, query)
+	if err != nil {
+		return nil, err
+	}
+	if names != nil {
+		argsV, err = reorderNamedArgs(names, argsV)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	args := namedValueToInterface(argsV)
 
-	commandTag, err := c.conn.Exec(ctx, query, args...)
+	var commandTag pgconn.CommandTag
+	if c.tracer != nil {
+		start := time.Now()
+		ctx = c.tracer.TraceQueryStart(ctx, c.conn, TraceQueryStartData{SQL: query, Args: args})
+		defer func() {
+			c.tracer.TraceQueryEnd(ctx, c.conn, TraceQueryEndData{CommandTag: commandTag, Err: err, Duration: time.Since(start)})
+		}()
+	}
+
+	commandTag, err = c.conn.Exec(ctx, query, args...)
 	// if we got a network error before we had a chance to send the query, retry
 	if err != nil {
 		if pgconn.SafeToRetry(err) {
@@ -346,11 +431,38 @@ func (c *Conn) QueryContext(ctx context.Context, query string, argsV []driver.Na
 		return nil, driver.ErrBadConn
 	}
 
+	query, names, err := rewriteNamedQuery(c.namedParamStyle, query)
+	if err != nil {
+		return nil, err
+	}
+	if names != nil {
+		argsV, err = reorderNamedArgs(names, argsV)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	args := []interface{}{databaseSQLResultFormats}
 	args = append(args, namedValueToInterface(argsV)...)
 
+	// Unlike ExecContext (which can report Duration and Err for the whole operation in one defer, since Exec runs
+	// to completion synchronously), a query's rows are still being fetched by the caller when QueryContext returns.
+	// So TraceQueryEnd is not called here except on an error that prevents a *Rows from ever being returned;
+	// on success, the *Rows itself carries the tracer and start time and reports TraceQueryEnd from Close, once the
+	// caller is actually done with the result set.
+	var tracerCtx context.Context
+	var start time.Time
+	if c.tracer != nil {
+		start = time.Now()
+		tracerCtx = c.tracer.TraceQueryStart(ctx, c.conn, TraceQueryStartData{SQL: query, Args: args[1:]})
+		ctx = tracerCtx
+	}
+
 	rows, err := c.conn.Query(ctx, query, args...)
 	if err != nil {
+		if c.tracer != nil {
+			c.tracer.TraceQueryEnd(tracerCtx, c.conn, TraceQueryEndData{Err: err, Duration: time.Since(start)})
+		}
 		if pgconn.SafeToRetry(err) {
 			return nil, driver.ErrBadConn
 		}
@@ -361,9 +473,15 @@ func (c *Conn) QueryContext(ctx context.Context, query string, argsV []driver.Na
 	more := rows.Next()
 	if err = rows.Err(); err != nil {
 		rows.Close()
+		if c.tracer != nil {
+			c.tracer.TraceQueryEnd(tracerCtx, c.conn, TraceQueryEndData{Err: err, Duration: time.Since(start)})
+		}
 		return nil, err
 	}
-	return &Rows{conn: c, rows: rows, skipNext: true, skipNextMore: more}, nil
+	return &Rows{
+		conn: c, rows: rows, skipNext: true, skipNextMore: more,
+		tracer: c.tracer, tracerCtx: tracerCtx, tracerStart: start,
+	}, nil
 }
 
 func (c *Conn) Ping(ctx context.Context) error {
@@ -375,8 +493,9 @@ func (c *Conn) Ping(ctx context.Context) error {
 }
 
 type Stmt struct {
-	sd   *pgconn.StatementDescription
-	conn *Conn
+	sd    *pgconn.StatementDescription
+	conn  *Conn
+	names []string // named parameters in first-use order, set by OptionNamedParameters; nil if unused
 }
 
 func (s *Stmt) Close() error {
@@ -394,6 +513,13 @@ func (s *Stmt) Exec(argsV []driver.Value) (driver.Result, error) {
 }
 
 func (s *Stmt) ExecContext(ctx context.Context, argsV []driver.NamedValue) (driver.Result, error) {
+	if s.names != nil {
+		var err error
+		argsV, err = reorderNamedArgs(s.names, argsV)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return s.conn.ExecContext(ctx, s.sd.Name, argsV)
 }
 
@@ -402,6 +528,13 @@ func (s *Stmt) Query(argsV []driver.Value) (driver.Rows, error) {
 }
 
 func (s *Stmt) QueryContext(ctx context.Context, argsV []driver.NamedValue) (driver.Rows, error) {
+	if s.names != nil {
+		var err error
+		argsV, err = reorderNamedArgs(s.names, argsV)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return s.conn.QueryContext(ctx, s.sd.Name, argsV)
 }
 
@@ -414,6 +547,14 @@ type Rows struct {
 	binaryDecoders []pgtype.BinaryDecoder
 	skipNext       bool
 	skipNextMore   bool
+
+	// tracer, tracerCtx and tracerStart are set by QueryContext when a QueryTracer is configured, so that Close can
+	// report TraceQueryEnd for the query's full lifetime (dispatch through the last row fetched and Close), rather
+	// than just the dispatch-plus-first-row-preload window QueryContext itself sees.
+	tracer      QueryTracer
+	tracerCtx   context.Context
+	tracerStart time.Time
+	lastErr     error // last non-io.EOF error seen from Next, reported to TraceQueryEnd by Close
 }
 
 func (r *Rows) Columns() []string {
@@ -493,6 +634,16 @@ func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 		return reflect.TypeOf(time.Time{})
 	case pgtype.ByteaOID:
 		return reflect.TypeOf([]byte(nil))
+	case pgtype.BoolArrayOID:
+		return reflect.TypeOf(BoolArray(nil))
+	case pgtype.ByteaArrayOID:
+		return reflect.TypeOf(ByteaArray(nil))
+	case pgtype.Float8ArrayOID:
+		return reflect.TypeOf(Float64Array(nil))
+	case pgtype.Int8ArrayOID:
+		return reflect.TypeOf(Int64Array(nil))
+	case pgtype.TextArrayOID:
+		return reflect.TypeOf(StringArray(nil))
 	default:
 		return reflect.TypeOf(new(interface{})).Elem()
 	}
@@ -500,6 +651,9 @@ func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 
 func (r *Rows) Close() error {
 	r.rows.Close()
+	if r.tracer != nil {
+		r.tracer.TraceQueryEnd(r.tracerCtx, r.conn.conn, TraceQueryEndData{Err: r.lastErr, Duration: time.Since(r.tracerStart)})
+	}
 	return nil
 }
 
@@ -623,11 +777,11 @@ func (r *Rows) Next(dest []driver.Value) error {
 	}
 
 	if !more {
-		if r.rows.Err() == nil {
+		r.lastErr = r.rows.Err()
+		if r.lastErr == nil {
 			return io.EOF
-		} else {
-			return r.rows.Err()
 		}
+		return r.lastErr
 	}
 
 	for i, rv := range r.rows.RawValues() {
@@ -635,19 +789,22 @@ func (r *Rows) Next(dest []driver.Value) error {
 		if fd.Format == pgx.BinaryFormatCode {
 			err := r.binaryDecoders[i].DecodeBinary(ci, rv)
 			if err != nil {
-				return fmt.Errorf("scan field %d failed: %v", i, err)
+				r.lastErr = fmt.Errorf("scan field %d failed: %v", i, err)
+				return r.lastErr
 			}
 		} else {
 			err := r.textDecoders[i].DecodeText(ci, rv)
 			if err != nil {
-				return fmt.Errorf("scan field %d failed: %v", i, err)
+				r.lastErr = fmt.Errorf("scan field %d failed: %v", i, err)
+				return r.lastErr
 			}
 		}
 
 		var err error
 		dest[i], err = r.driverValuers[i].Value()
 		if err != nil {
-			return fmt.Errorf("convert field %d failed: %v", i, err)
+			r.lastErr = fmt.Errorf("convert field %d failed: %v", i, err)
+			return r.lastErr
 		}
 	}
 
@@ -679,13 +836,29 @@ func namedValueToInterface(argsV []driver.NamedValue) []interface{} {
 }
 
 type wrapTx struct {
-	ctx context.Context
-	tx  pgx.Tx
+	ctx    context.Context
+	tx     pgx.Tx
+	conn   *pgx.Conn
+	tracer QueryTracer
 }
 
-func (wtx wrapTx) Commit() error { return wtx.tx.Commit(wtx.ctx) }
+func (wtx wrapTx) Commit() error {
+	start := time.Now()
+	err := wtx.tx.Commit(wtx.ctx)
+	if wtx.tracer != nil {
+		wtx.tracer.TraceTxEnd(wtx.ctx, wtx.conn, TraceTxEndData{Committed: true, Err: err, Duration: time.Since(start)})
+	}
+	return err
+}
 
-func (wtx wrapTx) Rollback() error { return wtx.tx.Rollback(wtx.ctx) }
+func (wtx wrapTx) Rollback() error {
+	start := time.Now()
+	err := wtx.tx.Rollback(wtx.ctx)
+	if wtx.tracer != nil {
+		wtx.tracer.TraceTxEnd(wtx.ctx, wtx.conn, TraceTxEndData{Committed: false, Err: err, Duration: time.Since(start)})
+	}
+	return err
+}
 
 type fakeTx struct{}
 
@@ -0,0 +1,80 @@
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// QueryTracer traces queries, connects, closes and transactions made through a *sql.DB opened with OpenDB and
+// OptionQueryTracer. It is distinct from pgx.ConnConfig.Logger: it sees the query text and []driver.NamedValue
+// database/sql itself passes to the driver (i.e. after OptionNamedParameters rewriting) and timings around
+// database/sql's own statement cache, neither of which pgx's logger can observe.
+//
+// Each TraceXStart method returns a context.Context that is threaded through to the matching TraceXEnd call,
+// allowing a tracer to carry per-operation state (e.g. a span) between the two.
+type QueryTracer interface {
+	// TraceQueryStart is called at the start of ExecContext, QueryContext and PrepareContext.
+	TraceQueryStart(ctx context.Context, conn *pgx.Conn, data TraceQueryStartData) context.Context
+	TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data TraceQueryEndData)
+
+	// TraceConnectStart is called at the start of connector.Connect.
+	TraceConnectStart(ctx context.Context, data TraceConnectStartData) context.Context
+	TraceConnectEnd(ctx context.Context, data TraceConnectEndData)
+
+	// TraceTxStart is called at the start of Conn.BeginTx.
+	TraceTxStart(ctx context.Context, conn *pgx.Conn, data TraceTxStartData) context.Context
+	TraceTxEnd(ctx context.Context, conn *pgx.Conn, data TraceTxEndData)
+
+	TraceClose(ctx context.Context, conn *pgx.Conn, data TraceCloseData)
+}
+
+// OptionQueryTracer configures a QueryTracer on the *sql.DB returned by OpenDB.
+func OptionQueryTracer(tracer QueryTracer) OptionOpenDB {
+	return func(dc *connector) {
+		dc.Tracer = tracer
+	}
+}
+
+type TraceQueryStartData struct {
+	SQL  string
+	Args []interface{}
+}
+
+// TraceQueryEndData is passed to TraceQueryEnd. For ExecContext, Duration and Err cover the whole (synchronous)
+// operation. For QueryContext, rows are still being fetched by the caller when the driver call returns, so
+// TraceQueryEnd instead fires when the returned *Rows is closed: Duration covers dispatch through the last row
+// fetched and Close, and Err is the last non-io.EOF error seen while iterating (if any), not just a dispatch
+// error.
+type TraceQueryEndData struct {
+	CommandTag pgconn.CommandTag
+	Err        error
+	Duration   time.Duration
+}
+
+type TraceConnectStartData struct {
+	ConnConfig *pgx.ConnConfig
+}
+
+type TraceConnectEndData struct {
+	Conn     *pgx.Conn
+	Err      error
+	Duration time.Duration
+}
+
+type TraceTxStartData struct {
+	Isolation pgx.TxIsoLevel
+	ReadOnly  bool
+}
+
+type TraceTxEndData struct {
+	Committed bool // true for Commit, false for Rollback
+	Err       error
+	Duration  time.Duration
+}
+
+type TraceCloseData struct {
+	Err error
+}
@@ -0,0 +1,144 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	errors "golang.org/x/xerrors"
+
+	"github.com/jackc/pgconn"
+)
+
+// SQLSTATEs that indicate a transaction was aborted due to a conflict with another transaction and is safe to retry
+// from the beginning.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// RetryConfig controls how RunInTx retries a transaction that fails with serialization_failure or
+// deadlock_detected. Set via OptionRetryOnSerializationFailure.
+type RetryConfig struct {
+	MaxAttempts int
+	Backoff     func(attempt int) time.Duration
+}
+
+// DefaultTxRetryBackoff is the default backoff used by RunInTx: a constant 10ms delay plus up to 10ms of jitter, so
+// that concurrent transactions that conflicted do not immediately conflict again on retry.
+func DefaultTxRetryBackoff(attempt int) time.Duration {
+	return 10*time.Millisecond + time.Duration(rand.Int63n(int64(10*time.Millisecond)))
+}
+
+// DefaultRetryConfig is the RetryConfig RunInTx uses for a *sql.DB opened without OptionRetryOnSerializationFailure:
+// up to 3 attempts with DefaultTxRetryBackoff.
+var DefaultRetryConfig = RetryConfig{MaxAttempts: 3, Backoff: DefaultTxRetryBackoff}
+
+// OptionRetryOnSerializationFailure configures RunInTx to automatically retry, up to max attempts in total, a
+// transaction run against this *sql.DB that fails with SQLSTATE 40001 (serialization_failure) or 40P01
+// (deadlock_detected), waiting backoff(attempt) between attempts. The policy is carried on the connector and then
+// each Conn (the same way OptionNamedParameters and OptionQueryTracer are), not a process-global map, so it does
+// not outlive or pin the *sql.DB. If this option is not used, RunInTx falls back to DefaultRetryConfig.
+func OptionRetryOnSerializationFailure(max int, backoff func(attempt int) time.Duration) OptionOpenDB {
+	return func(dc *connector) {
+		dc.RetryConfig = &RetryConfig{MaxAttempts: max, Backoff: backoff}
+	}
+}
+
+// RunInTx runs fn in a transaction started with opts. If fn, or the final Commit, fails with SQLSTATE 40001
+// (serialization_failure) or 40P01 (deadlock_detected), the transaction is restarted from the beginning in a fresh
+// BeginTx and fn is called again, up to db's configured RetryConfig.MaxAttempts in total (see
+// OptionRetryOnSerializationFailure; DefaultRetryConfig is used if db was opened without that option). Any other
+// error from fn or Commit is returned immediately without retrying.
+//
+// This is the idiomatic way to use sql.LevelSerializable: Postgres only detects a small subset of serialization
+// conflicts up front, relying on the client to retry transactions that it aborts later with serialization_failure.
+func RunInTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	configured, err := retryConfigFor(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	rc := DefaultRetryConfig
+	if configured != nil {
+		rc = *configured
+	}
+	if rc.MaxAttempts <= 0 {
+		rc.MaxAttempts = 1
+	}
+	if rc.Backoff == nil {
+		rc.Backoff = DefaultTxRetryBackoff
+	}
+
+	for attempt := 1; attempt <= rc.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(rc.Backoff(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = runTxOnce(ctx, db, opts, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// notPgxRetryConfig is a sentinel written into retryConfigFor's context value before BeginTx runs; a genuine
+// pgx Conn.BeginTx always overwrites it (with either a real *RetryConfig or nil), so it surviving BeginTx means db
+// was not opened with this package's driver.
+var notPgxRetryConfig = &RetryConfig{}
+
+// retryConfigFor retrieves the RetryConfig db was opened with via OptionRetryOnSerializationFailure, if any, by
+// briefly hijacking a pooled connection the same way AcquireConn does (see sql.go): the "transaction" it opens is
+// a no-op recognized by Conn.BeginTx and is committed (also a no-op) before returning, so this never actually
+// holds a connection open or starts a real transaction.
+func retryConfigFor(ctx context.Context, db *sql.DB) (*RetryConfig, error) {
+	rc := notPgxRetryConfig
+	tx, err := db.BeginTx(context.WithValue(ctx, ctxKeyRetryConfig, &rc), nil)
+	if err != nil {
+		return nil, err
+	}
+	if rc == notPgxRetryConfig {
+		tx.Rollback()
+		return nil, ErrNotPgx
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+func runTxOnce(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		// Postgres already rolled back the transaction server-side when it returned serialization_failure or
+		// deadlock_detected, so Rollback here is expected to fail with "transaction is closed" or similar. Swallow
+		// that and surface the original error instead.
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+			return true
+		}
+	}
+	return false
+}
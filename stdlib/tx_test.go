@@ -0,0 +1,30 @@
+package stdlib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"other error", errors.New("boom"), false},
+		{"serialization_failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock_detected", &pgconn.PgError{Code: "40P01"}, true},
+		{"unrelated pg error", &pgconn.PgError{Code: "23505"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableTxError(tt.err); got != tt.want {
+				t.Errorf("isRetryableTxError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}